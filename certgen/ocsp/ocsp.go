@@ -0,0 +1,135 @@
+// Package ocsp implements a minimal RFC 6960 OCSP responder on top of a tls.CertificateAuthority.
+package ocsp
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	ocsplib "golang.org/x/crypto/ocsp"
+
+	"tls-inspector/certificate-factory/certgen/tls"
+)
+
+// defaultValidity is how long a generated OCSP response is valid for before a client should
+// request a fresh one.
+const defaultValidity = 24 * time.Hour
+
+// errUnknownIssuer is returned by respond when the request's issuer name/key hash doesn't
+// match the CA we're responding for, per RFC 6960 section 2.3.
+var errUnknownIssuer = errors.New("ocsp: request is for an issuer this responder does not serve")
+
+// Handler returns an http.Handler implementing an OCSP responder backed by ca. It accepts
+// POST requests with an application/ocsp-request body as well as base64-encoded GET requests,
+// per RFC 6960 appendix A.1.
+func Handler(ca *tls.CertificateAuthority) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := readRequest(r)
+		if err != nil {
+			writeResponse(w, ocsplib.MalformedRequestErrorResponse)
+			return
+		}
+
+		req, err := ocsplib.ParseRequest(raw)
+		if err != nil {
+			writeResponse(w, ocsplib.MalformedRequestErrorResponse)
+			return
+		}
+
+		resp, err := respond(ca, req)
+		if errors.Is(err, errUnknownIssuer) {
+			writeResponse(w, ocsplib.UnauthorizedErrorResponse)
+			return
+		}
+		if err != nil {
+			writeResponse(w, ocsplib.InternalErrorErrorResponse)
+			return
+		}
+
+		writeResponse(w, resp)
+	})
+}
+
+func readRequest(r *http.Request) ([]byte, error) {
+	if r.Method == http.MethodPost {
+		return io.ReadAll(r.Body)
+	}
+
+	// GET /<base64-encoded DER request>
+	encoded := strings.TrimPrefix(r.URL.Path, "/")
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+func writeResponse(w http.ResponseWriter, der []byte) {
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(der)
+}
+
+func respond(ca *tls.CertificateAuthority, req *ocsplib.Request) ([]byte, error) {
+	match, err := requestMatchesIssuer(req, ca.IssuerCertificate().X509())
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, errUnknownIssuer
+	}
+
+	signer := ca.OCSPSigner()
+
+	template := ocsplib.Response{
+		SerialNumber: req.SerialNumber,
+		ThisUpdate:   time.Now(),
+		NextUpdate:   time.Now().Add(defaultValidity),
+		Certificate:  signer.X509(),
+	}
+
+	issued, ok := ca.Lookup(req.SerialNumber.String())
+	switch {
+	case !ok:
+		template.Status = ocsplib.Unknown
+	case issued.Revoked:
+		template.Status = ocsplib.Revoked
+		template.RevokedAt = issued.RevokedAt
+		template.RevocationReason = issued.RevocationReason
+	default:
+		template.Status = ocsplib.Good
+	}
+
+	return ocsplib.CreateResponse(ca.IssuerCertificate().X509(), signer.X509(), template, signer.Signer())
+}
+
+// requestMatchesIssuer reports whether req's issuer name/key hash identify issuer, following
+// the same hashing scheme ocsplib.CreateResponse uses to populate a response's CertID: the
+// hash (per req.HashAlgorithm) of issuer's raw subject, and of its subjectPublicKey bit string
+// right-aligned to a byte boundary.
+func requestMatchesIssuer(req *ocsplib.Request, issuer *x509.Certificate) (bool, error) {
+	hashFunc := req.HashAlgorithm
+	if !hashFunc.Available() {
+		return false, errors.New("ocsp: request uses an unsupported hash algorithm")
+	}
+
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return false, err
+	}
+
+	h := hashFunc.New()
+	h.Write(publicKeyInfo.PublicKey.RightAlign())
+	keyHash := h.Sum(nil)
+
+	h.Reset()
+	h.Write(issuer.RawSubject)
+	nameHash := h.Sum(nil)
+
+	return bytes.Equal(keyHash, req.IssuerKeyHash) && bytes.Equal(nameHash, req.IssuerNameHash), nil
+}
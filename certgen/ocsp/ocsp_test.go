@@ -0,0 +1,119 @@
+package ocsp
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ocsplib "golang.org/x/crypto/ocsp"
+
+	"tls-inspector/certificate-factory/certgen/tls"
+)
+
+func newTestCA(t *testing.T) *tls.CertificateAuthority {
+	t.Helper()
+
+	validity := tls.DateRange{
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+
+	root, err := tls.GenerateCertificate(tls.CertificateRequest{
+		Subject:                tls.Name{CommonName: "Test Root CA"},
+		Validity:               validity,
+		IsCertificateAuthority: true,
+		Usage:                  tls.KeyUsage{CertSign: true, CRLSign: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCertificate(root): %v", err)
+	}
+
+	ca, err := tls.NewCertificateAuthority(root, filepath.Join(t.TempDir(), "ca-state.json"), tls.StatusProviders{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority: %v", err)
+	}
+
+	return ca
+}
+
+func ocspRequestFor(t *testing.T, issuer *tls.Certificate, leaf *tls.Certificate) []byte {
+	t.Helper()
+
+	raw, err := ocsplib.CreateRequest(leaf.X509(), issuer.X509(), nil)
+	if err != nil {
+		t.Fatalf("ocsplib.CreateRequest: %v", err)
+	}
+	return raw
+}
+
+func TestHandlerRejectsRequestForForeignIssuer(t *testing.T) {
+	validity := tls.DateRange{
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+
+	caA := newTestCA(t)
+	caB := newTestCA(t)
+
+	leafB, err := caB.Issue(tls.CertificateRequest{
+		Subject:  tls.Name{CommonName: "leaf.example.com"},
+		Validity: validity,
+		Usage:    tls.KeyUsage{DigitalSignature: true, ServerAuth: true},
+	})
+	if err != nil {
+		t.Fatalf("caB.Issue: %v", err)
+	}
+
+	// Ask caA's responder about a certificate issued by caB - caA does not serve caB's issuer.
+	raw := ocspRequestFor(t, caB.IssuerCertificate(), leafB)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(raw))
+	Handler(caA).ServeHTTP(rec, req)
+
+	resp, err := ocsplib.ParseResponse(rec.Body.Bytes(), nil)
+	if err == nil {
+		t.Fatalf("expected an OCSP error response, got a signed response with status %d", resp.Status)
+	}
+
+	respErr, ok := err.(ocsplib.ResponseError)
+	if !ok {
+		t.Fatalf("expected ocsplib.ResponseError, got %T: %v", err, err)
+	}
+	if respErr.Status != ocsplib.Unauthorized {
+		t.Fatalf("response status = %v, want Unauthorized", respErr.Status)
+	}
+}
+
+func TestHandlerAnswersGoodForOwnIssuedCertificate(t *testing.T) {
+	validity := tls.DateRange{
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+
+	ca := newTestCA(t)
+	leaf, err := ca.Issue(tls.CertificateRequest{
+		Subject:  tls.Name{CommonName: "leaf.example.com"},
+		Validity: validity,
+		Usage:    tls.KeyUsage{DigitalSignature: true, ServerAuth: true},
+	})
+	if err != nil {
+		t.Fatalf("ca.Issue: %v", err)
+	}
+
+	raw := ocspRequestFor(t, ca.IssuerCertificate(), leaf)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(raw))
+	Handler(ca).ServeHTTP(rec, req)
+
+	resp, err := ocsplib.ParseResponse(rec.Body.Bytes(), ca.IssuerCertificate().X509())
+	if err != nil {
+		t.Fatalf("ocsplib.ParseResponse: %v", err)
+	}
+	if resp.Status != ocsplib.Good {
+		t.Fatalf("response status = %v, want Good", resp.Status)
+	}
+}
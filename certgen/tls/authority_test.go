@@ -0,0 +1,71 @@
+package tls
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCA builds a self-signed root CA (with both CertSign and CRLSign usage, so it can
+// serve as the issuer in CertificateAuthority, OCSP, and CRL tests alike) and a
+// CertificateAuthority backed by it, persisting state to a file under t.TempDir().
+func newTestCA(t *testing.T) (ca *CertificateAuthority, root *Certificate, statePath string) {
+	t.Helper()
+
+	root, err := GenerateCertificate(CertificateRequest{
+		Subject: Name{CommonName: "Test Root CA"},
+		Validity: DateRange{
+			NotBefore: time.Now().Add(-time.Hour),
+			NotAfter:  time.Now().Add(time.Hour),
+		},
+		IsCertificateAuthority: true,
+		Usage:                  KeyUsage{CertSign: true, CRLSign: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCertificate(root): %v", err)
+	}
+
+	statePath = filepath.Join(t.TempDir(), "ca-state.json")
+	ca, err = NewCertificateAuthority(root, statePath, StatusProviders{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority: %v", err)
+	}
+
+	return ca, root, statePath
+}
+
+func TestCertificateAuthorityIssuesVerifiableChain(t *testing.T) {
+	validity := DateRange{
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+
+	ca, root, statePath := newTestCA(t)
+
+	if !root.X509().IsCA {
+		t.Fatalf("root certificate IsCA = false, want true")
+	}
+
+	leaf, err := ca.Issue(CertificateRequest{
+		Subject:        Name{CommonName: "leaf.example.com"},
+		Validity:       validity,
+		AlternateNames: []AlternateName{{Type: AlternateNameTypeDNS, Value: "leaf.example.com"}},
+		Usage:          KeyUsage{DigitalSignature: true, KeyEncipherment: true, ServerAuth: true},
+	})
+	if err != nil {
+		t.Fatalf("ca.Issue(leaf): %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(root.X509())
+
+	if _, err := leaf.X509().Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err != nil {
+		t.Fatalf("leaf.Verify: %v", err)
+	}
+
+	if _, err := os.Stat(statePath); err != nil {
+		t.Fatalf("expected CA state file to exist: %v", err)
+	}
+}
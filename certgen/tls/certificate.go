@@ -3,11 +3,14 @@ package tls
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -16,6 +19,27 @@ import (
 	"time"
 )
 
+// KeyType describes the key algorithm used to generate a certificate's key pair
+type KeyType string
+
+const (
+	// KeyTypeEC256 generates a NIST P-256 ECDSA key
+	KeyTypeEC256 = KeyType("EC256")
+	// KeyTypeEC384 generates a NIST P-384 ECDSA key
+	KeyTypeEC384 = KeyType("EC384")
+	// KeyTypeRSA2048 generates a 2048-bit RSA key
+	KeyTypeRSA2048 = KeyType("RSA2048")
+	// KeyTypeRSA3072 generates a 3072-bit RSA key
+	KeyTypeRSA3072 = KeyType("RSA3072")
+	// KeyTypeRSA4096 generates a 4096-bit RSA key
+	KeyTypeRSA4096 = KeyType("RSA4096")
+	// KeyTypeEd25519 generates an Ed25519 key
+	KeyTypeEd25519 = KeyType("Ed25519")
+)
+
+// defaultKeyType is used when a CertificateRequest does not specify a KeyType
+const defaultKeyType = KeyTypeEC256
+
 // Name describes a X.509 name object
 type Name struct {
 	Organization string
@@ -182,6 +206,22 @@ type CertificateRequest struct {
 	Usage                  KeyUsage
 	IsCertificateAuthority bool
 	StatusProviders        StatusProviders
+	// KeyType is the key algorithm to generate for this certificate. Defaults to KeyTypeEC256
+	// when left empty.
+	KeyType KeyType
+	// MustStaple adds the TLS Feature (OCSP Must-Staple) extension, requiring clients to
+	// reject the certificate unless it is accompanied by a stapled OCSP response.
+	MustStaple bool
+	// IssuingCertificateURL is the set of Authority Information Access CA Issuers URLs,
+	// letting clients fetch the issuer certificate when it wasn't supplied out of band.
+	IssuingCertificateURL []string
+	// NameConstraints restricts the names a certificate authority is permitted to issue for.
+	// Only meaningful when IsCertificateAuthority is true.
+	NameConstraints NameConstraints
+	// ExtraExtensions are appended to the certificate's ExtraExtensions as-is, for extensions
+	// this package has no first-class support for (e.g. id-pkix-ocsp-nocheck on a delegated
+	// OCSP responder certificate).
+	ExtraExtensions []pkix.Extension
 }
 
 // StatusProviders describes providers for certificate status
@@ -190,6 +230,40 @@ type StatusProviders struct {
 	OCSP *string
 }
 
+// NameConstraints restricts the namespaces a certificate authority's subordinate
+// certificates are permitted or forbidden to use, per RFC 5280 section 4.2.1.10.
+type NameConstraints struct {
+	PermittedDNSDomains []string
+	ExcludedDNSDomains  []string
+
+	PermittedIPRanges []*net.IPNet
+	ExcludedIPRanges  []*net.IPNet
+
+	PermittedEmailAddresses []string
+	PermittedURIDomains     []string
+
+	// Critical marks the Name Constraints extension as critical (tpl.PermittedDNSDomainsCritical),
+	// as recommended by RFC 5280 for constrained CAs.
+	Critical bool
+}
+
+// tlsFeatureExtensionOID is the OID for the TLS Feature extension (RFC 7633), used here to
+// signal OCSP Must-Staple (TLS feature value 5).
+var tlsFeatureExtensionOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+// mustStapleExtension returns the ASN.1 DER encoding of a TLS Feature extension requesting
+// OCSP Must-Staple (a SEQUENCE containing the INTEGER 5).
+func mustStapleExtension() (pkix.Extension, error) {
+	value, err := asn1.Marshal([]int{5})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{
+		Id:    tlsFeatureExtensionOID,
+		Value: value,
+	}, nil
+}
+
 // Certificate describes a certificate
 type Certificate struct {
 	Serial               string
@@ -197,6 +271,10 @@ type Certificate struct {
 	CertificateAuthority bool
 	CertificateData      string
 	KeyData              string
+	// KeyType is the key algorithm this certificate was generated with. Re-issuing from this
+	// certificate as an issuer does not change the requested certificate's KeyType, but is
+	// recorded here so callers can keep a consistent algorithm across a chain.
+	KeyType KeyType
 }
 
 func (c Certificate) certificateDataBytes() []byte {
@@ -241,36 +319,97 @@ func (c Certificate) pKey() crypto.PrivateKey {
 	return k
 }
 
+// X509 returns the parsed x509.Certificate for this certificate. This will panic on an error,
+// but that shouldn't happen unless CertificateData was corrupted.
+func (c Certificate) X509() *x509.Certificate {
+	return c.x509()
+}
+
+// Signer returns the crypto.Signer for this certificate's private key, for callers outside
+// this package that need to sign with it directly (e.g. an OCSP responder). This will panic
+// on an error, but that shouldn't happen unless KeyData was corrupted or empty, as it is for
+// certificates produced by GenerateCertificateFromCSR.
+func (c Certificate) Signer() crypto.Signer {
+	return c.pKey().(crypto.Signer)
+}
+
 // GenerateCertificate will generate a certificate from the given certificate request
 func GenerateCertificate(request CertificateRequest, issuer *Certificate) (*Certificate, error) {
-	pKey, err := generateKey()
+	serial, err := randomSerialNumber()
 	if err != nil {
 		return nil, err
 	}
-	pub := pKey.(crypto.Signer).Public()
-	serial, err := randomSerialNumber()
+
+	return generateCertificate(request, issuer, serial)
+}
+
+// generateCertificate implements GenerateCertificate with an explicit serial number, so
+// callers such as CertificateAuthority can supply a monotonic serial instead of a random one.
+func generateCertificate(request CertificateRequest, issuer *Certificate, serial *big.Int) (*Certificate, error) {
+	keyType := request.KeyType
+	if keyType == "" {
+		keyType = defaultKeyType
+	}
+
+	pKey, err := generateKey(keyType)
 	if err != nil {
 		return nil, err
 	}
+	pub := pKey.(crypto.Signer).Public()
 
 	pKeyBytes, err := x509.MarshalPKCS8PrivateKey(pKey)
 	if err != nil {
 		return nil, err
 	}
 
-	publicKeyBytes, err := x509.MarshalPKIXPublicKey(pub)
+	signerPub := pub
+	if issuer != nil {
+		signerPub = issuer.x509().PublicKey
+	}
+
+	tpl, err := buildTemplate(request, serial, issuer, pub, signerPub)
 	if err != nil {
 		return nil, err
 	}
-	h := sha1.Sum(publicKeyBytes)
 
 	certificate := Certificate{
 		Serial:               serial.String(),
 		CertificateAuthority: issuer == nil,
 		KeyData:              hex.EncodeToString(pKeyBytes),
 		Subject:              request.Subject,
+		KeyType:              keyType,
+	}
+
+	var certBytes []byte
+
+	if issuer == nil {
+		certBytes, err = x509.CreateCertificate(rand.Reader, tpl, tpl, pub, pKey)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		certBytes, err = x509.CreateCertificate(rand.Reader, tpl, issuer.x509(), pub, issuer.pKey())
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	certificate.CertificateData = hex.EncodeToString(certBytes)
+	return &certificate, nil
+}
+
+// buildTemplate builds the x509.Certificate template shared by every issuance path
+// (GenerateCertificate, CertificateAuthority.Issue, and GenerateCertificateFromCSR). pub is
+// the certificate subject's own public key, used to derive its Subject Key Identifier.
+// signerPub is the public key that will sign the certificate (the issuer's, or pub itself for
+// a self-signed certificate), used to pick a matching signature algorithm default.
+func buildTemplate(request CertificateRequest, serial *big.Int, issuer *Certificate, pub, signerPub crypto.PublicKey) (*x509.Certificate, error) {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	h := sha1.Sum(publicKeyBytes)
+
 	tpl := &x509.Certificate{
 		SerialNumber:          serial,
 		Subject:               request.Subject.pkix(),
@@ -278,8 +417,14 @@ func GenerateCertificate(request CertificateRequest, issuer *Certificate) (*Cert
 		NotAfter:              request.Validity.NotAfter,
 		KeyUsage:              request.Usage.usage(),
 		BasicConstraintsValid: true,
+		IsCA:                  request.IsCertificateAuthority,
 		SubjectKeyId:          h[:],
-		ExtKeyUsage:           []x509.ExtKeyUsage{},
+		// request.Usage.extendedUsage() was previously never wired into the template, so every
+		// KeyUsage.ServerAuth/ClientAuth/etc. flag (and every certificate issued by this
+		// package before this change) was silently ignored. Needed here so a delegated OCSP
+		// responder certificate actually carries the OCSPSigning EKU.
+		ExtKeyUsage:        request.Usage.extendedUsage(),
+		SignatureAlgorithm: signatureAlgorithmForSigner(signerPub),
 	}
 
 	if issuer != nil {
@@ -321,22 +466,34 @@ func GenerateCertificate(request CertificateRequest, issuer *Certificate) (*Cert
 		tpl.OCSPServer = []string{*request.StatusProviders.OCSP}
 	}
 
-	var certBytes []byte
+	if len(request.IssuingCertificateURL) > 0 {
+		tpl.IssuingCertificateURL = request.IssuingCertificateURL
+	}
 
-	if issuer == nil {
-		certBytes, err = x509.CreateCertificate(rand.Reader, tpl, tpl, pub, pKey)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		certBytes, err = x509.CreateCertificate(rand.Reader, tpl, issuer.x509(), pub, issuer.pKey())
+	if request.MustStaple {
+		ext, err := mustStapleExtension()
 		if err != nil {
 			return nil, err
 		}
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, ext)
 	}
 
-	certificate.CertificateData = hex.EncodeToString(certBytes)
-	return &certificate, nil
+	tpl.ExtraExtensions = append(tpl.ExtraExtensions, request.ExtraExtensions...)
+
+	nc := request.NameConstraints
+	if len(nc.PermittedDNSDomains) > 0 || len(nc.ExcludedDNSDomains) > 0 ||
+		len(nc.PermittedIPRanges) > 0 || len(nc.ExcludedIPRanges) > 0 ||
+		len(nc.PermittedEmailAddresses) > 0 || len(nc.PermittedURIDomains) > 0 {
+		tpl.PermittedDNSDomainsCritical = nc.Critical
+		tpl.PermittedDNSDomains = nc.PermittedDNSDomains
+		tpl.ExcludedDNSDomains = nc.ExcludedDNSDomains
+		tpl.PermittedIPRanges = nc.PermittedIPRanges
+		tpl.ExcludedIPRanges = nc.ExcludedIPRanges
+		tpl.PermittedEmailAddresses = nc.PermittedEmailAddresses
+		tpl.PermittedURIDomains = nc.PermittedURIDomains
+	}
+
+	return tpl, nil
 }
 
 func randomSerialNumber() (*big.Int, error) {
@@ -344,6 +501,38 @@ func randomSerialNumber() (*big.Int, error) {
 	return rand.Int(rand.Reader, serialNumberLimit)
 }
 
-func generateKey() (crypto.PrivateKey, error) {
-	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+func generateKey(keyType KeyType) (crypto.PrivateKey, error) {
+	switch keyType {
+	case KeyTypeEC256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeEC384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyTypeEd25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+// signatureAlgorithmForSigner returns the template signature algorithm for a certificate
+// signed by signerPub (the issuer's public key, or the subject's own for a self-signed
+// certificate). RSA signers get a SHA-256 or SHA-384 digest based on key size; EC and Ed25519
+// signers are left at x509.UnknownSignatureAlgorithm so the stdlib picks the algorithm
+// matching the curve (or, for Ed25519, PureEd25519).
+func signatureAlgorithmForSigner(signerPub crypto.PublicKey) x509.SignatureAlgorithm {
+	rsaPub, ok := signerPub.(*rsa.PublicKey)
+	if !ok {
+		return x509.UnknownSignatureAlgorithm
+	}
+	if rsaPub.N.BitLen() > 3072 {
+		return x509.SHA384WithRSA
+	}
+	return x509.SHA256WithRSA
 }
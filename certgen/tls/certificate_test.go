@@ -0,0 +1,35 @@
+package tls
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestGenerateCertificateWiresExtendedKeyUsage(t *testing.T) {
+	cert, err := GenerateCertificate(CertificateRequest{
+		Subject: Name{CommonName: "leaf.example.com"},
+		Validity: DateRange{
+			NotBefore: time.Now().Add(-time.Hour),
+			NotAfter:  time.Now().Add(time.Hour),
+		},
+		Usage: KeyUsage{DigitalSignature: true, ServerAuth: true, ClientAuth: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCertificate: %v", err)
+	}
+
+	want := map[x509.ExtKeyUsage]bool{
+		x509.ExtKeyUsageServerAuth: true,
+		x509.ExtKeyUsageClientAuth: true,
+	}
+	got := cert.X509().ExtKeyUsage
+	if len(got) != len(want) {
+		t.Fatalf("ExtKeyUsage = %v, want %v", got, want)
+	}
+	for _, eku := range got {
+		if !want[eku] {
+			t.Fatalf("unexpected ExtKeyUsage %v in %v", eku, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// generatedCRL caches the last CRL produced by GenerateCRL, so repeated calls within the same
+// nextUpdate window and revocation state can be served without regenerating or persisting
+// anything.
+type generatedCRL struct {
+	der         []byte
+	nextUpdate  time.Time
+	revocations int
+}
+
+// GenerateCRL returns a DER-encoded X.509 v2 Certificate Revocation List, signed by the CA's
+// issuer key, covering every certificate this CertificateAuthority has revoked. If the
+// previously generated CRL is still within its validity window and no certificate has been
+// revoked since, that cached CRL is returned as-is; the CRL Number extension is only
+// incremented and persisted when a new CRL is actually generated.
+func (ca *CertificateAuthority) GenerateCRL(now time.Time, nextUpdate time.Duration) ([]byte, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	if ca.crl.der != nil && ca.revocations == ca.crl.revocations && now.Before(ca.crl.nextUpdate) {
+		return ca.crl.der, nil
+	}
+
+	var revoked []x509.RevocationListEntry
+	for _, issued := range ca.state.Issued {
+		if !issued.Revoked {
+			continue
+		}
+
+		serial, ok := new(big.Int).SetString(issued.Serial, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial number %s in issuance index", issued.Serial)
+		}
+
+		revoked = append(revoked, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: issued.RevokedAt,
+			ReasonCode:     issued.RevocationReason,
+		})
+	}
+
+	ca.state.CRLNumber.Add(ca.state.CRLNumber, big.NewInt(1))
+
+	tpl := &x509.RevocationList{
+		RevokedCertificateEntries: revoked,
+		Number:                    new(big.Int).Set(ca.state.CRLNumber),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(nextUpdate),
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, tpl, ca.issuer.x509(), ca.issuer.pKey().(crypto.Signer))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ca.save(); err != nil {
+		return nil, err
+	}
+
+	ca.crl = generatedCRL{der: der, nextUpdate: tpl.NextUpdate, revocations: ca.revocations}
+
+	return der, nil
+}
+
+// CRLHandler returns an http.Handler that serves this CertificateAuthority's current CRL,
+// generating a fresh one only when the cached CRL has expired or the revocation set has
+// changed since it was last generated. Mount it at whatever URL was baked into
+// StatusProviders.CRL.
+func (ca *CertificateAuthority) CRLHandler(nextUpdate time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		der, err := ca.GenerateCRL(time.Now(), nextUpdate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		w.Write(der)
+	})
+}
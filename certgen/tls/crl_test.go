@@ -0,0 +1,70 @@
+package tls
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestGenerateCRLCachesWithinValidityWindow(t *testing.T) {
+	ca, _, _ := newTestCA(t)
+	now := time.Now()
+
+	first, err := ca.GenerateCRL(now, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateCRL: %v", err)
+	}
+	firstNumber := new(big.Int).Set(ca.state.CRLNumber)
+
+	second, err := ca.GenerateCRL(now.Add(time.Minute), time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateCRL (cached): %v", err)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Fatalf("GenerateCRL returned a different CRL within the validity window")
+	}
+	if ca.state.CRLNumber.Cmp(firstNumber) != 0 {
+		t.Fatalf("CRLNumber changed from %s to %s despite no revocation", firstNumber, ca.state.CRLNumber)
+	}
+}
+
+func TestGenerateCRLRegeneratesAfterRevocation(t *testing.T) {
+	ca, _, _ := newTestCA(t)
+	now := time.Now()
+
+	leaf, err := ca.Issue(CertificateRequest{
+		Subject: Name{CommonName: "leaf.example.com"},
+		Validity: DateRange{
+			NotBefore: now.Add(-time.Hour),
+			NotAfter:  now.Add(time.Hour),
+		},
+		Usage: KeyUsage{DigitalSignature: true, ServerAuth: true},
+	})
+	if err != nil {
+		t.Fatalf("ca.Issue: %v", err)
+	}
+
+	first, err := ca.GenerateCRL(now, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateCRL: %v", err)
+	}
+	firstNumber := new(big.Int).Set(ca.state.CRLNumber)
+
+	if err := ca.Revoke(leaf.Serial, 0); err != nil {
+		t.Fatalf("ca.Revoke: %v", err)
+	}
+
+	second, err := ca.GenerateCRL(now.Add(time.Minute), time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateCRL (after revoke): %v", err)
+	}
+
+	if bytes.Equal(first, second) {
+		t.Fatalf("GenerateCRL returned a stale CRL after a revocation")
+	}
+	if ca.state.CRLNumber.Cmp(firstNumber) != 1 {
+		t.Fatalf("CRLNumber did not increase after a revocation: still %s", ca.state.CRLNumber)
+	}
+}
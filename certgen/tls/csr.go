@@ -0,0 +1,58 @@
+package tls
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+)
+
+// GenerateCertificateFromCSR signs a PKCS#10 certificate signing request, reusing the CSR's
+// public key rather than generating a new keypair. Subject, SAN, usage, and validity are taken
+// from request rather than the CSR, as a CSR's own subject/extension fields are not trusted
+// input. This lets the factory sign keys generated on HSMs, YubiKeys, or remote clients, which
+// GenerateCertificate cannot do because it always generates its own keypair.
+//
+// The returned Certificate's KeyData is empty, since the caller - not this package - holds the
+// private key.
+func GenerateCertificateFromCSR(csrPEM []byte, request CertificateRequest, issuer *Certificate) (*Certificate, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("no PEM-encoded certificate request found")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("csr signature verification failed: %w", err)
+	}
+
+	if issuer == nil {
+		return nil, fmt.Errorf("issuer is required to sign a certificate request")
+	}
+
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	tpl, err := buildTemplate(request, serial, issuer, csr.PublicKey, issuer.x509().PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, tpl, issuer.x509(), csr.PublicKey, issuer.pKey())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificate{
+		Serial:          serial.String(),
+		Subject:         request.Subject,
+		CertificateData: hex.EncodeToString(certBytes),
+	}, nil
+}
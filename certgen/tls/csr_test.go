@@ -0,0 +1,112 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func newTestCSR(t *testing.T, commonName string) (csrPEM []byte, key *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey: %v", err)
+	}
+
+	tpl := &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tpl, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificateRequest: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), key
+}
+
+func newTestIssuer(t *testing.T) *Certificate {
+	t.Helper()
+
+	issuer, err := GenerateCertificate(CertificateRequest{
+		Subject:                Name{CommonName: "Test Root CA"},
+		Validity:               DateRange{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)},
+		IsCertificateAuthority: true,
+		Usage:                  KeyUsage{CertSign: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCertificate(issuer): %v", err)
+	}
+	return issuer
+}
+
+func TestGenerateCertificateFromCSRUsesRequestFieldsNotCSR(t *testing.T) {
+	csrPEM, key := newTestCSR(t, "untrusted-csr-subject.example.com")
+	issuer := newTestIssuer(t)
+
+	cert, err := GenerateCertificateFromCSR(csrPEM, CertificateRequest{
+		Subject:  Name{CommonName: "trusted.example.com"},
+		Validity: DateRange{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)},
+		AlternateNames: []AlternateName{
+			{Type: AlternateNameTypeDNS, Value: "trusted.example.com"},
+		},
+		Usage: KeyUsage{DigitalSignature: true, ServerAuth: true},
+	}, issuer)
+	if err != nil {
+		t.Fatalf("GenerateCertificateFromCSR: %v", err)
+	}
+
+	x := cert.X509()
+	if x.Subject.CommonName != "trusted.example.com" {
+		t.Fatalf("Subject.CommonName = %q, want the request's subject, not the CSR's", x.Subject.CommonName)
+	}
+	if len(x.DNSNames) != 1 || x.DNSNames[0] != "trusted.example.com" {
+		t.Fatalf("DNSNames = %v, want [trusted.example.com] from request.AlternateNames", x.DNSNames)
+	}
+
+	if !x.PublicKey.(*ecdsa.PublicKey).Equal(&key.PublicKey) {
+		t.Fatalf("issued certificate's public key does not match the CSR's key")
+	}
+
+	if cert.KeyData != "" {
+		t.Fatalf("KeyData = %q, want empty since the caller holds the private key", cert.KeyData)
+	}
+}
+
+func TestGenerateCertificateFromCSRRejectsBadSignature(t *testing.T) {
+	csrPEM, _ := newTestCSR(t, "leaf.example.com")
+	issuer := newTestIssuer(t)
+
+	// Corrupt a byte inside the DER payload, inside the PEM block, to invalidate the CSR's
+	// self-signature without touching the PEM framing.
+	block, _ := pem.Decode(csrPEM)
+	block.Bytes[len(block.Bytes)-1] ^= 0xFF
+	tampered := pem.EncodeToMemory(block)
+
+	_, err := GenerateCertificateFromCSR(tampered, CertificateRequest{
+		Subject:  Name{CommonName: "leaf.example.com"},
+		Validity: DateRange{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)},
+		Usage:    KeyUsage{DigitalSignature: true, ServerAuth: true},
+	}, issuer)
+	if err == nil {
+		t.Fatalf("GenerateCertificateFromCSR with a tampered signature: got nil error, want one")
+	}
+}
+
+func TestGenerateCertificateFromCSRRequiresIssuer(t *testing.T) {
+	csrPEM, _ := newTestCSR(t, "leaf.example.com")
+
+	_, err := GenerateCertificateFromCSR(csrPEM, CertificateRequest{
+		Subject:  Name{CommonName: "leaf.example.com"},
+		Validity: DateRange{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)},
+		Usage:    KeyUsage{DigitalSignature: true, ServerAuth: true},
+	}, nil)
+	if err == nil {
+		t.Fatalf("GenerateCertificateFromCSR with a nil issuer: got nil error, want one")
+	}
+}
@@ -0,0 +1,67 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// PEM returns this certificate's certificate and private key, each PEM-encoded. If this
+// certificate has no key (as for one produced by GenerateCertificateFromCSR), keyPEM is nil.
+func (c Certificate) PEM() (certPEM []byte, keyPEM []byte, err error) {
+	certPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: c.certificateDataBytes(),
+	})
+
+	if c.KeyData == "" {
+		return certPEM, nil, nil
+	}
+
+	keyPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: c.keyDataBytes(),
+	})
+
+	return certPEM, keyPEM, nil
+}
+
+// Chain returns this certificate followed by the given issuers, each PEM-encoded and
+// concatenated in order, suitable for serving as a certificate chain bundle.
+func (c Certificate) Chain(issuers ...*Certificate) []byte {
+	var buf bytes.Buffer
+	buf.Write(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: c.certificateDataBytes(),
+	}))
+
+	for _, issuer := range issuers {
+		buf.Write(pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: issuer.certificateDataBytes(),
+		}))
+	}
+
+	return buf.Bytes()
+}
+
+// PKCS12 bundles this certificate, its private key, and the given CA chain into a password
+// protected PFX archive, suitable for import into Windows/macOS keychains and browsers. It
+// returns an error if this certificate has no key (as for one produced by
+// GenerateCertificateFromCSR), since a PFX archive has nothing to bundle in that case.
+func (c Certificate) PKCS12(password string, caChain []*Certificate) ([]byte, error) {
+	if c.KeyData == "" {
+		return nil, fmt.Errorf("certificate has no private key to bundle")
+	}
+
+	caCerts := make([]*x509.Certificate, len(caChain))
+	for i, ca := range caChain {
+		caCerts[i] = ca.x509()
+	}
+
+	return pkcs12.Modern.WithRand(rand.Reader).Encode(c.pKey(), c.x509(), caCerts, password)
+}
@@ -0,0 +1,154 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+func newTestLeaf(t *testing.T, issuer *Certificate) *Certificate {
+	t.Helper()
+
+	leaf, err := GenerateCertificate(CertificateRequest{
+		Subject: Name{CommonName: "leaf.example.com"},
+		Validity: DateRange{
+			NotBefore: time.Now().Add(-time.Hour),
+			NotAfter:  time.Now().Add(time.Hour),
+		},
+		Usage: KeyUsage{DigitalSignature: true, ServerAuth: true},
+	}, issuer)
+	if err != nil {
+		t.Fatalf("GenerateCertificate: %v", err)
+	}
+	return leaf
+}
+
+func TestCertificatePEM(t *testing.T) {
+	leaf := newTestLeaf(t, nil)
+
+	certPEM, keyPEM, err := leaf.PEM()
+	if err != nil {
+		t.Fatalf("PEM: %v", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil || certBlock.Type != "CERTIFICATE" {
+		t.Fatalf("certPEM did not decode to a CERTIFICATE block: %+v", certBlock)
+	}
+	if _, err := x509.ParseCertificate(certBlock.Bytes); err != nil {
+		t.Fatalf("certPEM block does not parse as a certificate: %v", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil || keyBlock.Type != "PRIVATE KEY" {
+		t.Fatalf("keyPEM did not decode to a PRIVATE KEY block: %+v", keyBlock)
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes); err != nil {
+		t.Fatalf("keyPEM block does not parse as a private key: %v", err)
+	}
+}
+
+func TestCertificatePEMWithoutKey(t *testing.T) {
+	leaf := newTestLeaf(t, nil)
+	leaf.KeyData = ""
+
+	certPEM, keyPEM, err := leaf.PEM()
+	if err != nil {
+		t.Fatalf("PEM: %v", err)
+	}
+	if keyPEM != nil {
+		t.Fatalf("keyPEM = %q, want nil for a certificate with no key", keyPEM)
+	}
+	if block, _ := pem.Decode(certPEM); block == nil {
+		t.Fatalf("certPEM did not decode")
+	}
+}
+
+func TestCertificateChain(t *testing.T) {
+	root, err := GenerateCertificate(CertificateRequest{
+		Subject:                Name{CommonName: "Test Root CA"},
+		Validity:               DateRange{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)},
+		IsCertificateAuthority: true,
+		Usage:                  KeyUsage{CertSign: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCertificate(root): %v", err)
+	}
+	leaf := newTestLeaf(t, root)
+
+	chain := leaf.Chain(root)
+
+	var blocks []*pem.Block
+	rest := chain
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) != 2 {
+		t.Fatalf("Chain produced %d PEM blocks, want 2", len(blocks))
+	}
+	leafCert, err := x509.ParseCertificate(blocks[0].Bytes)
+	if err != nil {
+		t.Fatalf("parsing leaf block: %v", err)
+	}
+	if leafCert.Subject.CommonName != "leaf.example.com" {
+		t.Fatalf("first block CommonName = %q, want the leaf first", leafCert.Subject.CommonName)
+	}
+	rootCert, err := x509.ParseCertificate(blocks[1].Bytes)
+	if err != nil {
+		t.Fatalf("parsing issuer block: %v", err)
+	}
+	if rootCert.Subject.CommonName != "Test Root CA" {
+		t.Fatalf("second block CommonName = %q, want the issuer second", rootCert.Subject.CommonName)
+	}
+}
+
+func TestCertificatePKCS12(t *testing.T) {
+	root, err := GenerateCertificate(CertificateRequest{
+		Subject:                Name{CommonName: "Test Root CA"},
+		Validity:               DateRange{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)},
+		IsCertificateAuthority: true,
+		Usage:                  KeyUsage{CertSign: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCertificate(root): %v", err)
+	}
+	leaf := newTestLeaf(t, root)
+
+	pfx, err := leaf.PKCS12("hunter2", []*Certificate{root})
+	if err != nil {
+		t.Fatalf("PKCS12: %v", err)
+	}
+
+	_, cert, caCerts, err := pkcs12.DecodeChain(pfx, "hunter2")
+	if err != nil {
+		t.Fatalf("pkcs12.DecodeChain: %v", err)
+	}
+	if cert.Subject.CommonName != "leaf.example.com" {
+		t.Fatalf("decoded certificate CommonName = %q, want leaf.example.com", cert.Subject.CommonName)
+	}
+	if len(caCerts) != 1 || caCerts[0].Subject.CommonName != "Test Root CA" {
+		t.Fatalf("decoded CA chain = %+v, want [Test Root CA]", caCerts)
+	}
+	if !bytes.Equal(cert.Raw, leaf.x509().Raw) {
+		t.Fatalf("decoded certificate does not match the original")
+	}
+}
+
+func TestCertificatePKCS12WithoutKeyErrors(t *testing.T) {
+	leaf := newTestLeaf(t, nil)
+	leaf.KeyData = ""
+
+	if _, err := leaf.PKCS12("hunter2", nil); err == nil {
+		t.Fatalf("PKCS12 on a certificate with no key: got nil error, want one")
+	}
+}